@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3x/resource"
+)
+
+// Canceled is the error returned by Err when the context was cancelled.
+var Canceled = errors.New("context canceled")
+
+// DeadlineExceeded is the error returned by Err when the context's deadline
+// has passed.
+var DeadlineExceeded = errors.New("context deadline exceeded")
+
+// newDerivedContext returns an unpooled context whose Value lookups and
+// cancellation fall back to parent.
+func newDerivedContext(parent Context) *ctx {
+	return &ctx{parent: parent}
+}
+
+// propagateCancel arranges for child to be cancelled when parent is, reusing
+// the existing closer bookkeeping rather than spawning a watcher goroutine
+// per derived context.
+func propagateCancel(parent Context, child *ctx) {
+	if parent == nil {
+		return
+	}
+
+	if parent.IsClosed() {
+		child.cancel(errOrCanceled(parent.Err()))
+		return
+	}
+
+	parent.RegisterCloser(resource.CloserFn(func() error {
+		child.cancel(errOrCanceled(parent.Err()))
+		return nil
+	}))
+}
+
+func errOrCanceled(err error) error {
+	if err != nil {
+		return err
+	}
+	return Canceled
+}
+
+// WithCancel returns a child of parent (which may be nil) along with a
+// CancelFunc. Calling the CancelFunc, closing parent, or cancelling parent
+// all close the returned context's Done channel.
+func WithCancel(parent Context) (Context, CancelFunc) {
+	child := newDerivedContext(parent)
+	propagateCancel(parent, child)
+	return child, func() { child.cancel(Canceled) }
+}
+
+// WithDeadline returns a child of parent (which may be nil) that is
+// automatically cancelled with DeadlineExceeded once d passes.
+func WithDeadline(parent Context, d time.Time) (Context, CancelFunc) {
+	if parent != nil {
+		// parent is cancelled first, so its earlier deadline is also ours.
+		if pd, ok := parent.Deadline(); ok && pd.Before(d) {
+			d = pd
+		}
+	}
+
+	child := newDerivedContext(parent)
+	child.deadline, child.hasDeadline = d, true
+	propagateCancel(parent, child)
+
+	cancel := func() { child.cancel(Canceled) }
+
+	dur := time.Until(d)
+	if dur <= 0 {
+		child.cancel(DeadlineExceeded)
+		return child, cancel
+	}
+
+	timer := time.AfterFunc(dur, func() { child.cancel(DeadlineExceeded) })
+	return child, func() {
+		timer.Stop()
+		cancel()
+	}
+}
+
+// WithTimeout returns WithDeadline(parent, time.Now().Add(d)).
+func WithTimeout(parent Context, d time.Duration) (Context, CancelFunc) {
+	return WithDeadline(parent, time.Now().Add(d))
+}
+
+// WithValue returns a child of parent with key associated with val. Lookups
+// of other keys fall through to parent.
+func WithValue(parent Context, key, val interface{}) Context {
+	if key == nil {
+		panic("context: nil key")
+	}
+
+	child := newDerivedContext(parent)
+	child.values = map[interface{}]interface{}{key: val}
+	propagateCancel(parent, child)
+	return child
+}
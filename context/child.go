@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+// childRegisterer is implemented by every Context in this package so
+// NewChildContext can register a child without requiring it on the public
+// Context interface.
+type childRegisterer interface {
+	registerChild(child Context)
+}
+
+// canceller is implemented by every Context in this package so a closing
+// parent can cascade its real error (e.g. DeadlineExceeded) to its children
+// instead of always cancelling them with the generic Canceled error.
+type canceller interface {
+	cancel(err error)
+}
+
+// NewChildContext returns a new context that is a child of parent: closing
+// or cancelling parent closes the child ahead of parent's own finalizers,
+// and the child inherits parent's deadline and cancellation (see Deadline,
+// Done, Err). The child may still close itself early without affecting
+// parent or any siblings. Use DependsOn instead when a full parent/child
+// relationship is overkill and a lighter-weight ordering barrier suffices.
+func NewChildContext(parent Context) Context {
+	child := newDerivedContext(parent)
+
+	if parent != nil {
+		if p, ok := parent.(childRegisterer); ok {
+			p.registerChild(child)
+		} else {
+			// parent isn't backed by *ctx (e.g. an external Context
+			// implementation): fall back to the propagateCancel used by
+			// WithCancel so the child still inherits cancellation. The
+			// child is then closed as one of parent's registered closers
+			// rather than ahead of parent's finalizers.
+			propagateCancel(parent, child)
+		}
+	}
+
+	return child
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewChildContextInheritsParentCancelReason(t *testing.T) {
+	parent := NewContext()
+	child := NewChildContext(parent)
+
+	parent.Close()
+
+	<-child.Done()
+	if child.Err() != Canceled {
+		t.Fatalf("expected child.Err() to be Canceled, got %v", child.Err())
+	}
+}
+
+func TestNewChildContextInheritsParentDeadlineReason(t *testing.T) {
+	parent, cancel := WithTimeout(NewContext(), time.Millisecond)
+	defer cancel()
+	child := NewChildContext(parent)
+
+	<-child.Done()
+
+	if got, want := parent.Err(), DeadlineExceeded; got != want {
+		t.Fatalf("expected parent.Err() to be %v, got %v", want, got)
+	}
+	if got, want := child.Err(), DeadlineExceeded; got != want {
+		t.Fatalf("expected child.Err() to inherit %v, got %v", want, got)
+	}
+}
+
+func TestNewChildContextOfAlreadyClosedParentInheritsReason(t *testing.T) {
+	parent, cancel := WithTimeout(NewContext(), 0)
+	defer cancel()
+
+	<-parent.Done()
+	if got, want := parent.Err(), DeadlineExceeded; got != want {
+		t.Fatalf("expected parent.Err() to be %v, got %v", want, got)
+	}
+
+	child := NewChildContext(parent)
+
+	<-child.Done()
+	if got, want := child.Err(), DeadlineExceeded; got != want {
+		t.Fatalf("expected child of an already-closed parent to inherit %v, got %v", want, got)
+	}
+}
@@ -22,18 +22,43 @@ package context
 
 import (
 	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
 
 	"github.com/m3db/m3x/resource"
 )
 
 // NB(r): using golang.org/x/net/context is too GC expensive.
 type ctx struct {
-	sync.RWMutex
+	spinLock
 
 	pool          contextPool
 	done          bool
 	wg            sync.WaitGroup
 	finalizeables []finalizeable
+
+	parent      Context
+	err         error
+	deadline    time.Time
+	hasDeadline bool
+	values      map[interface{}]interface{}
+
+	// doneCh and doneClosed have their own, separate lock: registerFinalizeable,
+	// DependsOn and close need to read doneCh to decide whether to bail out of
+	// spinLock's cancellable wait *before* they've acquired the main lock, so
+	// it can't be guarded by the same lock they're about to wait on. doneClosed
+	// makes "has doneCh been closed yet" and "close it now" a single atomic
+	// decision under doneMu, so a racing Done() allocating the channel can
+	// never close it a second time after close() already has (or vice versa).
+	doneMu     sync.Mutex
+	doneCh     chan struct{}
+	doneClosed bool
+
+	finalizeOpts *FinalizeOptions
+	finalizeSem  *semaphore.Weighted
+
+	children []Context
 }
 
 type finalizeable struct {
@@ -52,13 +77,23 @@ func newPooledContext(pool contextPool) Context {
 }
 
 func (c *ctx) IsClosed() bool {
-	c.RLock()
+	c.Lock()
 	done := c.done
-	c.RUnlock()
+	c.Unlock()
 
 	return done
 }
 
+// peekDoneCh returns the done channel if one has already been allocated (only
+// Done allocates it), or nil otherwise. It never allocates, so contexts
+// that never need cancellation don't pay for it.
+func (c *ctx) peekDoneCh() <-chan struct{} {
+	c.doneMu.Lock()
+	ch := c.doneCh
+	c.doneMu.Unlock()
+	return ch
+}
+
 func (c *ctx) RegisterFinalizer(f resource.Finalizer) {
 	c.registerFinalizeable(finalizeable{finalizer: f})
 }
@@ -67,8 +102,83 @@ func (c *ctx) RegisterCloser(f resource.Closer) {
 	c.registerFinalizeable(finalizeable{closer: f})
 }
 
+func (c *ctx) RegisterLock(l resource.Lock) {
+	c.RegisterCloser(resource.CloserFn(l.Unlock))
+}
+
+// registerChild registers child so it is closed, ahead of this context's own
+// finalizers, whenever this context closes. If this context is already
+// closed, child is cancelled immediately, with this context's real error
+// (e.g. DeadlineExceeded), to avoid leaking it.
+func (c *ctx) registerChild(child Context) {
+	c.Lock()
+	if c.done {
+		err := c.err
+		c.Unlock()
+		if cc, ok := child.(canceller); ok {
+			cc.cancel(err)
+		} else {
+			child.Close()
+		}
+		return
+	}
+
+	if c.children != nil {
+		c.children = append(c.children, child)
+		c.Unlock()
+		return
+	}
+
+	if c.pool != nil {
+		c.children = append(c.pool.getChildren(), child)
+	} else {
+		c.children = append(allocateChildren(), child)
+	}
+
+	c.Unlock()
+}
+
+func allocateChildren() []Context {
+	return make([]Context, 0, defaultInitChildrenCap)
+}
+
+// Children returns a snapshot of this context's child contexts. A child that
+// closed early stays listed until this context closes. It is intended mainly
+// for debugging and tests.
+func (c *ctx) Children() []Context {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.children) == 0 {
+		return nil
+	}
+
+	children := make([]Context, len(c.children))
+	copy(children, c.children)
+	return children
+}
+
+func (c *ctx) SetFinalizeOptions(opts FinalizeOptions) {
+	var sem *semaphore.Weighted
+	if opts.MaxConcurrency > 0 {
+		sem = semaphore.NewWeighted(opts.MaxConcurrency)
+	}
+
+	c.Lock()
+	c.finalizeOpts = &opts
+	c.finalizeSem = sem
+	c.Unlock()
+}
+
 func (c *ctx) registerFinalizeable(f finalizeable) {
-	if c.Lock(); c.done {
+	if err := c.lockCancellable(c.peekDoneCh()); err != nil {
+		// Already cancelled and the lock is contended: don't park waiting
+		// to register something that would just be dropped, as it is below
+		// once the context is done.
+		return
+	}
+
+	if c.done {
 		c.Unlock()
 		return
 	}
@@ -92,8 +202,74 @@ func allocateFinalizeables() []finalizeable {
 	return make([]finalizeable, 0, defaultInitFinalizersCap)
 }
 
-func (c *ctx) DependsOn(blocker Context) {
+func (c *ctx) Deadline() (time.Time, bool) {
+	c.Lock()
+	d, ok, parent := c.deadline, c.hasDeadline, c.parent
+	c.Unlock()
+
+	if ok {
+		return d, true
+	}
+	if parent != nil {
+		return parent.Deadline()
+	}
+	return time.Time{}, false
+}
+
+func (c *ctx) Done() <-chan struct{} {
+	c.doneMu.Lock()
+	if c.doneCh == nil {
+		c.doneCh = make(chan struct{})
+		if c.doneClosed {
+			close(c.doneCh)
+		}
+	}
+	ch := c.doneCh
+	c.doneMu.Unlock()
+	return ch
+}
+
+func (c *ctx) Err() error {
+	c.Lock()
+	err := c.err
+	c.Unlock()
+	return err
+}
+
+func (c *ctx) Value(key interface{}) interface{} {
+	c.Lock()
+	v, ok := c.values[key]
+	parent := c.parent
+	c.Unlock()
+
+	if ok {
+		return v
+	}
+	if parent != nil {
+		return parent.Value(key)
+	}
+	return nil
+}
+
+// cancel marks the context as cancelled with err and runs it through the
+// same finalize pipeline as an explicit Close, so registered closers and
+// any dependents queued via DependsOn are unblocked exactly once.
+func (c *ctx) cancel(err error) {
 	c.Lock()
+	if c.done {
+		c.Unlock()
+		return
+	}
+	c.err = err
+	c.Unlock()
+
+	c.close(closeAsync)
+}
+
+func (c *ctx) DependsOn(blocker Context) {
+	if err := c.lockCancellable(c.peekDoneCh()); err != nil {
+		return
+	}
 
 	if !c.done {
 		c.wg.Add(1)
@@ -124,61 +300,85 @@ func (c *ctx) BlockingClose() {
 }
 
 func (c *ctx) close(mode closeMode) {
-	if c.Lock(); c.done {
+	if err := c.lockCancellable(c.peekDoneCh()); err != nil {
+		// Already cancelled and contended: whoever is driving the
+		// cancellation through to completion will finish this close.
+		return
+	}
+	if c.done {
 		c.Unlock()
 		return
 	}
 
 	c.done = true
-	c.Unlock()
+	if c.err == nil {
+		c.err = Canceled
+	}
 
-	if c.finalizeables == nil {
-		c.returnToPool()
-		return
+	// doneClosed and the close(c.doneCh) it guards must happen as a single
+	// decision under doneMu: storing a "cancelled" signal outside doneMu and
+	// then separately closing the channel lets a concurrent Done() allocate
+	// the channel, observe the signal, and close it itself before this
+	// reaches the lock below, producing a double close.
+	c.doneMu.Lock()
+	if !c.doneClosed {
+		c.doneClosed = true
+		if c.doneCh != nil {
+			close(c.doneCh)
+		}
 	}
+	c.doneMu.Unlock()
 
-	// Capture finalizeables to avoid concurrent r/w if Reset
-	// is used after a caller waits for the finalizers to finish
+	// Capture children and finalizeables to avoid concurrent r/w if Reset
+	// is used after a caller waits for the finalizers to finish.
+	children := c.children
+	c.children = nil
 	f := c.finalizeables
 	c.finalizeables = nil
 
-	switch mode {
-	case closeAsync:
-		go c.finalize(f)
-	case closeBlock:
-		c.finalize(f)
-	}
-}
-
-func (c *ctx) finalize(f []finalizeable) {
-	// Wait for dependencies.
-	c.wg.Wait()
+	err := c.err
+	c.Unlock()
 
-	// Now call finalizers.
-	for i := range f {
-		if f[i].finalizer != nil {
-			f[i].finalizer.Finalize()
-			f[i].finalizer = nil
-		}
-		if f[i].closer != nil {
-			f[i].closer.Close()
-			f[i].closer = nil
+	// Close children ahead of this context's own finalizers, propagating the
+	// real reason this context closed (e.g. DeadlineExceeded) rather than
+	// always cancelling them with the generic Canceled error.
+	for _, child := range children {
+		if cc, ok := child.(canceller); ok {
+			cc.cancel(err)
+		} else {
+			child.Close()
 		}
 	}
+	if c.pool != nil && children != nil {
+		c.pool.putChildren(children[:0])
+	}
 
-	if c.pool != nil {
-		c.pool.putFinalizeables(f)
+	if f == nil {
+		c.returnToPool()
+		return
 	}
 
-	c.returnToPool()
+	switch mode {
+	case closeAsync:
+		go c.finalize(f)
+	case closeBlock:
+		c.finalize(f)
+	}
 }
 
 func (c *ctx) Reset() {
 	c.Lock()
 
-	c.done, c.finalizeables = false, nil
+	c.done, c.finalizeables, c.children = false, nil, nil
+	c.parent, c.err = nil, nil
+	c.deadline, c.hasDeadline, c.values = time.Time{}, false, nil
+	c.finalizeOpts, c.finalizeSem = nil, nil
 
 	c.Unlock()
+
+	c.doneMu.Lock()
+	c.doneCh, c.doneClosed = nil, false
+	c.doneMu.Unlock()
 }
 
 func (c *ctx) returnToPool() {
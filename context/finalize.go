@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	stdlibcontext "context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// FinalizeOptions controls how a context's finalizers and closers are run.
+// The zero value reproduces the original behavior: finalizers and closers
+// run serially, with no timeout, and Closer errors are discarded.
+type FinalizeOptions struct {
+	// MaxConcurrency bounds how many finalizers/closers may run at once. If
+	// zero, finalizers and closers run serially on the closing goroutine,
+	// same as before FinalizeOptions existed.
+	MaxConcurrency int64
+
+	// PerItemTimeout bounds how long a single Finalize()/Close() call may
+	// run. If it is exceeded, the item is abandoned to a background reaper
+	// goroutine and the pipeline moves on without it; AbandonedFinalizerCount
+	// is incremented. Zero means unbounded.
+	PerItemTimeout time.Duration
+
+	// OnError, if non-nil, is called with any error returned by a
+	// Closer.Close() (previously always discarded).
+	OnError func(error)
+}
+
+var (
+	defaultFinalizeOptsMu sync.RWMutex
+	defaultFinalizeOpts   FinalizeOptions
+	defaultFinalizeSem    *semaphore.Weighted
+
+	abandonedFinalizerCount int64
+)
+
+// SetDefaultFinalizeOptions sets the package-level FinalizeOptions used by
+// contexts that have not called SetFinalizeOptions themselves. Its
+// MaxConcurrency budget is shared by every such context, e.g. all contexts
+// drawn from a single pool.
+func SetDefaultFinalizeOptions(opts FinalizeOptions) {
+	var sem *semaphore.Weighted
+	if opts.MaxConcurrency > 0 {
+		sem = semaphore.NewWeighted(opts.MaxConcurrency)
+	}
+
+	defaultFinalizeOptsMu.Lock()
+	defaultFinalizeOpts = opts
+	defaultFinalizeSem = sem
+	defaultFinalizeOptsMu.Unlock()
+}
+
+func getDefaultFinalizeOptions() (FinalizeOptions, *semaphore.Weighted) {
+	defaultFinalizeOptsMu.RLock()
+	opts, sem := defaultFinalizeOpts, defaultFinalizeSem
+	defaultFinalizeOptsMu.RUnlock()
+	return opts, sem
+}
+
+// AbandonedFinalizerCount returns the number of finalizers/closers that
+// exceeded their PerItemTimeout and were abandoned to a background reaper.
+func AbandonedFinalizerCount() int64 {
+	return atomic.LoadInt64(&abandonedFinalizerCount)
+}
+
+func (c *ctx) resolveFinalizeOptions() (FinalizeOptions, *semaphore.Weighted) {
+	c.Lock()
+	opts, sem := c.finalizeOpts, c.finalizeSem
+	c.Unlock()
+
+	if opts != nil {
+		return *opts, sem
+	}
+
+	return getDefaultFinalizeOptions()
+}
+
+func (c *ctx) finalize(f []finalizeable) {
+	// Wait for dependencies.
+	c.wg.Wait()
+
+	opts, sem := c.resolveFinalizeOptions()
+
+	if sem == nil {
+		// No MaxConcurrency configured: preserve the original serial
+		// behavior on this goroutine.
+		for i := range f {
+			runFinalizeable(f[i], opts)
+			f[i] = finalizeable{}
+		}
+	} else {
+		// BlockingClose should only wait for this context's own items, so
+		// this WaitGroup is local to this call rather than shared across
+		// the contexts that share sem's concurrency budget.
+		var wg sync.WaitGroup
+		for i := range f {
+			item := f[i]
+			f[i] = finalizeable{}
+			if item.finalizer == nil && item.closer == nil {
+				continue
+			}
+
+			if err := sem.Acquire(stdlibcontext.Background(), 1); err != nil {
+				// Acquire only errs if its context is cancelled, which
+				// context.Background() never is; fall back to running the
+				// item inline rather than dropping it.
+				runFinalizeable(item, opts)
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer sem.Release(1)
+				runFinalizeable(item, opts)
+			}()
+		}
+		wg.Wait()
+	}
+
+	if c.pool != nil {
+		c.pool.putFinalizeables(f)
+	}
+
+	c.returnToPool()
+}
+
+func runFinalizeable(f finalizeable, opts FinalizeOptions) {
+	if opts.PerItemTimeout <= 0 {
+		runFinalizeableNow(f, opts.OnError)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		runFinalizeableNow(f, opts.OnError)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(opts.PerItemTimeout):
+		atomic.AddInt64(&abandonedFinalizerCount, 1)
+		// The goroutine above is left running as a background reaper. Any
+		// semaphore slot held by the caller is released as soon as this
+		// returns, not when the abandoned item eventually completes.
+	}
+}
+
+func runFinalizeableNow(f finalizeable, onError func(error)) {
+	if f.finalizer != nil {
+		f.finalizer.Finalize()
+	}
+	if f.closer != nil {
+		if err := f.closer.Close(); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
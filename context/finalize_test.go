@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/resource"
+)
+
+func TestContextFinalizeBoundsConcurrency(t *testing.T) {
+	c := NewContext()
+	c.SetFinalizeOptions(FinalizeOptions{MaxConcurrency: 2})
+
+	const numClosers = 10
+	var (
+		mu      sync.Mutex
+		running int
+		maxSeen int
+	)
+	var wg sync.WaitGroup
+	wg.Add(numClosers)
+
+	for i := 0; i < numClosers; i++ {
+		c.RegisterCloser(resource.CloserFn(func() error {
+			defer wg.Done()
+
+			mu.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}))
+	}
+
+	c.Close()
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("closers did not all run in time")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxSeen > 2 {
+		t.Fatalf("expected at most 2 closers running concurrently, saw %d", maxSeen)
+	}
+}
+
+func TestContextFinalizePerItemTimeoutAbandonsSlowCloser(t *testing.T) {
+	c := NewContext()
+	c.SetFinalizeOptions(FinalizeOptions{PerItemTimeout: 10 * time.Millisecond})
+
+	before := AbandonedFinalizerCount()
+
+	unblock := make(chan struct{})
+	var ran int32
+	c.RegisterCloser(resource.CloserFn(func() error {
+		<-unblock
+		atomic.StoreInt32(&ran, 1)
+		return nil
+	}))
+
+	c.BlockingClose()
+
+	if got := AbandonedFinalizerCount(); got != before+1 {
+		t.Fatalf("expected AbandonedFinalizerCount to increase by 1, went from %d to %d", before, got)
+	}
+
+	close(unblock)
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&ran) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&ran) == 0 {
+		t.Fatal("expected the abandoned closer to still run to completion in the background")
+	}
+}
+
+func TestContextFinalizeOnErrorReceivesCloserError(t *testing.T) {
+	c := NewContext()
+
+	wantErr := errors.New("boom")
+	var gotErr error
+	var mu sync.Mutex
+	c.SetFinalizeOptions(FinalizeOptions{
+		OnError: func(err error) {
+			mu.Lock()
+			gotErr = err
+			mu.Unlock()
+		},
+	})
+	c.RegisterCloser(resource.CloserFn(func() error { return wantErr }))
+
+	c.BlockingClose()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if gotErr != wantErr {
+		t.Fatalf("expected OnError to receive %v, got %v", wantErr, gotErr)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"sync"
+
+	"github.com/m3db/m3x/resource"
+)
+
+// ReleaseFunc releases a lock acquired from a LockManager. Calling it more
+// than once has no effect after the first call.
+type ReleaseFunc func()
+
+// LockManager hands out keyed, in-process mutexes without ever exposing the
+// underlying sync.Mutex, so a caller can't accidentally keep using a lock
+// after releasing it. Entries for keys with no outstanding holders are
+// garbage collected.
+type LockManager struct {
+	mu      sync.Mutex
+	entries map[string]*lockEntry
+}
+
+type lockEntry struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewLockManager creates a new LockManager.
+func NewLockManager() *LockManager {
+	return &LockManager{entries: make(map[string]*lockEntry)}
+}
+
+func (m *LockManager) acquireEntry(key string) *lockEntry {
+	m.mu.Lock()
+	e, ok := m.entries[key]
+	if !ok {
+		e = &lockEntry{}
+		m.entries[key] = e
+	}
+	e.refs++
+	m.mu.Unlock()
+	return e
+}
+
+func (m *LockManager) releaseEntry(key string, e *lockEntry) {
+	e.mu.Unlock()
+
+	m.mu.Lock()
+	e.refs--
+	if e.refs == 0 {
+		delete(m.entries, key)
+	}
+	m.mu.Unlock()
+}
+
+// Acquire blocks until the in-process lock for key is held or ctx is done,
+// whichever comes first, matching the rest of this package's design
+// principle that a waiter shouldn't be parked on a lock for a request
+// that's already been given up on. On success it returns a ReleaseFunc that
+// releases the lock exactly once; the release is also registered with ctx
+// via RegisterLock, so it still runs when ctx closes even if the caller
+// never invokes the returned ReleaseFunc directly. On cancellation it
+// returns ctx.Err() and a nil ReleaseFunc.
+func (m *LockManager) Acquire(ctx Context, key string) (ReleaseFunc, error) {
+	e := m.acquireEntry(key)
+
+	acquired := make(chan struct{})
+	go func() {
+		e.mu.Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+	case <-ctx.Done():
+		// The goroutine above will still eventually acquire the lock; once
+		// it does, release it straight back rather than hold it forever for
+		// a caller that's no longer waiting.
+		go func() {
+			<-acquired
+			m.releaseEntry(key, e)
+		}()
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	release := ReleaseFunc(func() {
+		once.Do(func() { m.releaseEntry(key, e) })
+	})
+
+	ctx.RegisterLock(resource.LockFn(func() error {
+		release()
+		return nil
+	}))
+
+	return release, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockManagerAcquireRelease(t *testing.T) {
+	m := NewLockManager()
+	ctx := NewContext()
+
+	release, err := m.Acquire(ctx, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	// A second acquire on the same key should now succeed immediately.
+	ctx2 := NewContext()
+	release2, err := m.Acquire(ctx2, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release2()
+}
+
+func TestLockManagerAcquireCancelledDoesNotBlockForever(t *testing.T) {
+	m := NewLockManager()
+
+	holderCtx := NewContext()
+	release, err := m.Acquire(holderCtx, "foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	waiterCtx, cancel := WithCancel(NewContext())
+	cancel()
+
+	done := make(chan struct{})
+	var acquireErr error
+	go func() {
+		_, acquireErr = m.Acquire(waiterCtx, "foo")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not return promptly for an already-cancelled ctx")
+	}
+
+	if acquireErr == nil {
+		t.Fatal("expected a non-nil error from Acquire with an already-cancelled ctx")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+// defaultInitFinalizersCap is the default capacity of a freshly allocated
+// finalizeables slice.
+const defaultInitFinalizersCap = 4
+
+// defaultInitChildrenCap is the default capacity of a freshly allocated
+// children slice.
+const defaultInitChildrenCap = 4
+
+// contextPool pools contexts and the backing storage for their
+// finalizeable and children slices.
+type contextPool interface {
+	// Get returns a context from the pool.
+	Get() Context
+
+	// Put returns a context to the pool.
+	Put(c Context)
+
+	// getFinalizeables returns a finalizeables slice from the pool.
+	getFinalizeables() []finalizeable
+
+	// putFinalizeables returns a finalizeables slice to the pool.
+	putFinalizeables(f []finalizeable)
+
+	// getChildren returns a children slice from the pool.
+	getChildren() []Context
+
+	// putChildren returns a children slice to the pool.
+	putChildren(c []Context)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// spinLockMaxAttempts bounds how many times Lock busy-spins with
+// runtime.Gosched() before parking on the underlying sync.Mutex. Spinning
+// even for this in-memory, single-process lock is worthwhile because a
+// context may be cancelled out from under a waiter, and a parked goroutine
+// can't notice that until it is eventually woken.
+const spinLockMaxAttempts = 1000
+
+// ErrContextCancelled is returned by a spinLock's cancellable lock methods
+// when done fires before the lock could be acquired.
+var ErrContextCancelled = errors.New("context: already cancelled")
+
+// spinLock is a mutex that busy-spins briefly before falling back to
+// sync.Mutex, and whose cancellable variants can bail out early once a
+// caller-supplied done channel closes, rather than park indefinitely for a
+// lock that will no longer do the caller any good. This matches the design
+// principle that a context with a cancelled/aborted request should not park
+// goroutines waiting on its lock, even when the lock itself is purely
+// in-memory.
+type spinLock struct {
+	mu sync.Mutex
+}
+
+// Lock acquires the lock, spinning briefly before parking.
+func (s *spinLock) Lock() {
+	// A nil done channel makes lockCancellable behave like a plain Lock;
+	// the error is always nil in that case.
+	_ = s.lockCancellable(nil)
+}
+
+// Unlock releases the lock.
+func (s *spinLock) Unlock() {
+	s.mu.Unlock()
+}
+
+// lockCancellable is like Lock, but if done is non-nil and closes before the
+// lock is acquired, it returns ErrContextCancelled instead of blocking
+// further. If the lock is won concurrently with done closing, the win takes
+// priority and nil is returned.
+func (s *spinLock) lockCancellable(done <-chan struct{}) error {
+	for i := 0; i < spinLockMaxAttempts; i++ {
+		if s.mu.TryLock() {
+			return nil
+		}
+		if done != nil {
+			select {
+			case <-done:
+				return ErrContextCancelled
+			default:
+			}
+		}
+		runtime.Gosched()
+	}
+
+	if done == nil {
+		s.mu.Lock()
+		return nil
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		s.mu.Lock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		return nil
+	case <-done:
+		// Both may be ready at once; the win takes priority.
+		select {
+		case <-acquired:
+			return nil
+		default:
+		}
+		// The goroutine above will still eventually acquire the lock; hand
+		// it straight back once it does rather than hold it forever.
+		go func() {
+			<-acquired
+			s.mu.Unlock()
+		}()
+		return ErrContextCancelled
+	}
+}
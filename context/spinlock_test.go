@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// TestContextDoneCloseRace exercises a concurrent Done() (which allocates and
+// may close doneCh) racing close() (which also closes doneCh) many times
+// over. Before the doneMu-guarded doneClosed fix, this reliably panicked
+// with "close of closed channel" within a handful of iterations under
+// -race.
+func TestContextDoneCloseRace(t *testing.T) {
+	for i := 0; i < 2000; i++ {
+		c := NewContext()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-c.Done()
+		}()
+		go func() {
+			defer wg.Done()
+			c.Close()
+		}()
+		wg.Wait()
+
+		select {
+		case <-c.Done():
+		default:
+			t.Fatalf("iteration %d: Done() channel not closed after Close()", i)
+		}
+	}
+}
+
+// BenchmarkSpinLockUncontended measures the cost of the fast path (TryLock
+// always wins) against which a plain sync.Mutex is compared below.
+func BenchmarkSpinLockUncontended(b *testing.B) {
+	var s spinLock
+	for i := 0; i < b.N; i++ {
+		s.Lock()
+		s.Unlock()
+	}
+}
+
+func BenchmarkMutexUncontended(b *testing.B) {
+	var mu sync.Mutex
+	for i := 0; i < b.N; i++ {
+		mu.Lock()
+		mu.Unlock()
+	}
+}
+
+// BenchmarkSpinLockContended and BenchmarkMutexContended measure throughput
+// when GOMAXPROCS goroutines all hammer the same lock, none of them ever
+// cancelling.
+func BenchmarkSpinLockContended(b *testing.B) {
+	var s spinLock
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Lock()
+			s.Unlock()
+		}
+	})
+}
+
+func BenchmarkMutexContended(b *testing.B) {
+	var mu sync.Mutex
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			mu.Unlock()
+		}
+	})
+}
+
+// BenchmarkSpinLockContendedCancellable measures the mean time per call for a waiter
+// racing lockCancellable against an already-closed done channel while other
+// goroutines hold the lock for a while, the scenario spinLock exists for:
+// a cancelled caller should return quickly via ErrContextCancelled instead
+// of parking for the lock like a plain sync.Mutex would.
+func BenchmarkSpinLockContendedCancellable(b *testing.B) {
+	var s spinLock
+	done := make(chan struct{})
+	close(done)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s.Lock()
+				runtime.Gosched()
+				s.Unlock()
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// When the lock happens to be free the call wins it; hand it back
+		// so the holders above keep contending.
+		if err := s.lockCancellable(done); err == nil {
+			s.Unlock()
+		}
+	}
+	b.StopTimer()
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestSpinLockCancellable(t *testing.T) {
+	var s spinLock
+
+	s.Lock()
+	done := make(chan struct{})
+	close(done)
+
+	if err := s.lockCancellable(done); err != ErrContextCancelled {
+		t.Fatalf("expected ErrContextCancelled while locked with a closed done channel, got %v", err)
+	}
+
+	s.Unlock()
+
+	if err := s.lockCancellable(nil); err != nil {
+		t.Fatalf("expected uncontended lock to succeed, got %v", err)
+	}
+	s.Unlock()
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	stdlibcontext "context"
+	"time"
+)
+
+// ToStdContext adapts c to the stdlib context.Context interface. Context
+// already implements Deadline/Done/Err/Value with matching signatures, so
+// this is a zero-allocation type assertion rather than a wrapper.
+func ToStdContext(c Context) stdlibcontext.Context {
+	return c.(stdlibcontext.Context)
+}
+
+// FromStdContext adapts a stdlib context.Context into a Context, so it can
+// be passed to code that registers finalizers/closers. If sCtx was produced
+// by ToStdContext its underlying *ctx is recovered directly with no
+// allocation; otherwise it is wrapped in a bridge that proxies cancellation.
+func FromStdContext(sCtx stdlibcontext.Context) Context {
+	if c, ok := sCtx.(Context); ok {
+		return c
+	}
+
+	b := &stdContextBridge{ctx: ctx{}, std: sCtx}
+	if done := sCtx.Done(); done != nil {
+		go func() {
+			<-done
+			b.ctx.cancel(errOrCanceled(sCtx.Err()))
+		}()
+	}
+	return b
+}
+
+// stdContextBridge adapts a foreign stdlib context.Context to the Context
+// interface, proxying Deadline/Done/Err to the wrapped context while reusing
+// ctx's finalizer/closer bookkeeping for RegisterFinalizer/RegisterCloser.
+type stdContextBridge struct {
+	ctx
+	std stdlibcontext.Context
+}
+
+func (b *stdContextBridge) Deadline() (time.Time, bool) {
+	return b.std.Deadline()
+}
+
+func (b *stdContextBridge) Done() <-chan struct{} {
+	return b.std.Done()
+}
+
+func (b *stdContextBridge) Err() error {
+	return b.std.Err()
+}
+
+func (b *stdContextBridge) Value(key interface{}) interface{} {
+	if v := b.ctx.Value(key); v != nil {
+		return v
+	}
+	return b.std.Value(key)
+}
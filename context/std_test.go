@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package context
+
+import (
+	stdlibcontext "context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3x/resource"
+)
+
+func TestToStdContextRoundTrip(t *testing.T) {
+	c := NewContext()
+	std := ToStdContext(c)
+
+	if back := FromStdContext(std); back != c {
+		t.Fatal("expected FromStdContext(ToStdContext(c)) to recover c with no wrapping")
+	}
+}
+
+func TestFromStdContextProxiesForeignContext(t *testing.T) {
+	sCtx, cancel := stdlibcontext.WithTimeout(stdlibcontext.Background(), time.Millisecond)
+	defer cancel()
+
+	c := FromStdContext(sCtx)
+
+	var finalized int32
+	c.RegisterFinalizer(resource.FinalizerFn(func() { atomic.StoreInt32(&finalized, 1) }))
+
+	<-c.Done()
+
+	if got, want := c.Err(), stdlibcontext.DeadlineExceeded; got != want {
+		t.Fatalf("expected bridged Err() to proxy the foreign context's error, got %v want %v", got, want)
+	}
+
+	// Give the foreign context's Done() watcher goroutine a chance to close
+	// the bridge and run the finalizer.
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&finalized) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if atomic.LoadInt32(&finalized) == 0 {
+		t.Fatal("expected registering a finalizer on a bridged context to eventually run it")
+	}
+}
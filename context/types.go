@@ -0,0 +1,100 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package context provides a context that is aware of finalizers and closers
+// that should be executed once a request is complete, avoiding the GC
+// pressure of the standard library's context package on the hot path while
+// remaining compatible with it at the edges.
+package context
+
+import (
+	"time"
+
+	"github.com/m3db/m3x/resource"
+)
+
+// CancelFunc cancels a Context, signalling to any code that it should
+// abandon its work. A CancelFunc does not wait for the work to stop.
+// Calling a CancelFunc more than once has no effect after the first call.
+type CancelFunc func()
+
+// Context provides context on a request.
+type Context interface {
+	// IsClosed returns whether the context has been closed.
+	IsClosed() bool
+
+	// RegisterFinalizer will register a resource finalizer.
+	RegisterFinalizer(f resource.Finalizer)
+
+	// RegisterCloser will register a resource closer.
+	RegisterCloser(f resource.Closer)
+
+	// RegisterLock will register a lock whose Unlock is queued into the
+	// same finalize pipeline as RegisterCloser, guaranteeing it is released
+	// exactly once when the context closes.
+	RegisterLock(l resource.Lock)
+
+	// SetFinalizeOptions overrides, for this context only, how its
+	// finalizers and closers are run (see FinalizeOptions). If not called,
+	// the package-level default set via SetDefaultFinalizeOptions applies.
+	SetFinalizeOptions(opts FinalizeOptions)
+
+	// Children returns a snapshot of this context's child contexts, as
+	// created by NewChildContext. A child that closed early stays listed
+	// until this context closes. It is intended mainly for debugging and
+	// tests.
+	Children() []Context
+
+	// DependsOn will register a blocking context that must close before
+	// finalizers can be run.
+	DependsOn(blocker Context)
+
+	// Finalize handles a call from another context that was depended upon
+	// via DependsOn closing.
+	Finalize()
+
+	// Close will close the context.
+	Close()
+
+	// BlockingClose will close the context and wait for finalizers to run.
+	BlockingClose()
+
+	// Reset will reset the context for reuse.
+	Reset()
+
+	// Deadline returns the time when this context will be cancelled, if any.
+	// The second return value is false when no deadline is set.
+	Deadline() (time.Time, bool)
+
+	// Done returns a channel that is closed when this context is cancelled
+	// or its deadline expires. Done may return nil if this context can
+	// never be cancelled.
+	Done() <-chan struct{}
+
+	// Err returns nil while Done is not yet closed. Once Done is closed,
+	// Err returns a non-nil error explaining why: Canceled if the context
+	// was cancelled or DeadlineExceeded if the deadline passed.
+	Err() error
+
+	// Value returns the value associated with this context for key, or nil
+	// if no value is associated with key. Successive calls with the same
+	// key return the same result.
+	Value(key interface{}) interface{}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package etcdlock adapts etcd v3 concurrency locks to resource.Lock so they
+// can be released via Context.RegisterLock.
+package etcdlock
+
+import (
+	"context"
+	"time"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/m3db/m3x/resource"
+)
+
+// unlockTimeout bounds how long Unlock waits for the release Delete to
+// complete. It is deliberately independent of the context passed to
+// NewMutex: that context is scoped to acquisition and is typically
+// deadline-bounded, so by the time Unlock runs (often well after
+// acquisition, e.g. from a registered Context.RegisterLock closer) it may
+// already be expired, which would make every Unlock fail without the
+// distributed lock's key ever being deleted.
+const unlockTimeout = 5 * time.Second
+
+// Mutex wraps an already-acquired etcd v3 concurrency mutex, adapting it to
+// resource.Lock.
+type Mutex struct {
+	mutex *concurrency.Mutex
+}
+
+// NewMutex acquires a distributed lock for key within session and returns a
+// Mutex wrapping it. ctx bounds how long acquisition will wait.
+func NewMutex(ctx context.Context, session *concurrency.Session, key string) (*Mutex, error) {
+	m := concurrency.NewMutex(session, key)
+	if err := m.Lock(ctx); err != nil {
+		return nil, err
+	}
+
+	return &Mutex{mutex: m}, nil
+}
+
+// Unlock releases the distributed lock, using a context of its own rather
+// than the (possibly long-expired) context acquisition was bounded by.
+func (m *Mutex) Unlock() error {
+	ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+	defer cancel()
+
+	return m.mutex.Unlock(ctx)
+}
+
+var _ resource.Lock = (*Mutex)(nil)
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package resource contains interfaces for resources that need to be
+// released, finalized or closed, generally in response to a context closing.
+package resource
+
+// Finalizer is implemented by objects that can be finalized.
+type Finalizer interface {
+	Finalize()
+}
+
+// FinalizerFn implements the Finalizer interface using a function literal,
+// similar in spirit to http.HandlerFunc.
+type FinalizerFn func()
+
+// Finalize calls the underlying function.
+func (f FinalizerFn) Finalize() {
+	f()
+}
+
+// Closer is implemented by objects that can be closed.
+type Closer interface {
+	Close() error
+}
+
+// CloserFn implements the Closer interface using a function literal.
+type CloserFn func() error
+
+// Close calls the underlying function.
+func (c CloserFn) Close() error {
+	return c()
+}